@@ -0,0 +1,81 @@
+// Package alerts fires configurable alerts against the harness's tracked
+// CID map, inspired by ipfs-cluster's alerts feature: CIDs that go
+// under-provided, CIDs whose reprovide gap grows too large, and a daemon
+// that's gone quiet. Alerts are delivered to pluggable sinks with dedup
+// and a cool-down, and Engine.Failed reports whether any alert has fired.
+// The harness stops a node once its alerts fire; its exit status does
+// not reflect fired alerts.
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SinksConfig selects which Sinks BuildSinks should construct.
+type SinksConfig struct {
+	// Stderr, if true, prints a one-line message per alert.
+	Stderr bool `yaml:"stderr"`
+	// JSONLPath, if set, appends one JSON object per alert to this file.
+	JSONLPath string `yaml:"jsonlPath"`
+	// WebhookURL, if set, POSTs each alert as a JSON body to this URL.
+	WebhookURL string `yaml:"webhookURL"`
+}
+
+// Config describes the alert rules and sinks to run with. It is normally
+// loaded from a YAML file via LoadConfig so thresholds can be tuned in CI
+// without recompiling the harness.
+type Config struct {
+	// UnderProvidedFactor is the multiplier applied to the reprovide
+	// interval beyond which a CID with zero provide records is
+	// considered under-provided (rule a).
+	UnderProvidedFactor float64 `yaml:"underProvidedFactor"`
+	// StaleGapFactor is the multiplier applied to the reprovide interval
+	// beyond which a gap between two provides for the same CID fires a
+	// stale-gap alert (rule b).
+	StaleGapFactor float64 `yaml:"staleGapFactor"`
+	// SilentDaemonFactor is the multiplier applied to the reprovide
+	// interval beyond which no "sent provider record" line being
+	// observed on daemon stderr fires an alert (rule c). Like the other
+	// two factors, this scales with the reprovide interval: Kubo only
+	// emits these lines once per sweep, so a fixed absolute duration
+	// would false-positive on any interval longer than it.
+	SilentDaemonFactor float64 `yaml:"silentDaemonFactor"`
+	// CoolDown is the minimum time between two deliveries of the same
+	// alert, deduped by rule name + CID.
+	CoolDown time.Duration `yaml:"coolDown"`
+
+	Sinks SinksConfig `yaml:"sinks"`
+}
+
+// DefaultConfig mirrors the thresholds the request that introduced this
+// package called out: 1.5x the reprovide interval for under-provided
+// CIDs, 2x for stale gaps, 2x for a silent daemon, a stderr sink, and no
+// webhook/file delivery unless configured.
+func DefaultConfig() Config {
+	return Config{
+		UnderProvidedFactor: 1.5,
+		StaleGapFactor:      2,
+		SilentDaemonFactor:  2,
+		CoolDown:            10 * time.Minute,
+		Sinks:               SinksConfig{Stderr: true},
+	}
+}
+
+// LoadConfig reads and parses a YAML alerts configuration from path,
+// starting from DefaultConfig so a partial file only overrides what it
+// sets.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read alerts config: %w", err)
+	}
+	cfg := DefaultConfig()
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse alerts config: %w", err)
+	}
+	return cfg, nil
+}
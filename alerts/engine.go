@@ -0,0 +1,127 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CIDState is the subset of a tracked CID's provide history the rules
+// need. Callers (typically the harness's monitoring loop) feed this in
+// via CheckCID on whatever cadence they already poll at.
+type CIDState struct {
+	CID      string
+	Count    int
+	LastSeen time.Time
+}
+
+// Engine evaluates the under-provided / stale-gap / silent-daemon rules
+// and delivers fired alerts to the configured sinks, deduped by rule+CID
+// within a cool-down window.
+type Engine struct {
+	cfg               Config
+	sinks             []Sink
+	start             time.Time
+	reprovideInterval time.Duration
+
+	mu                 sync.Mutex
+	lastFired          map[string]time.Time
+	lastDaemonActivity time.Time
+	failed             bool
+}
+
+// NewEngine builds an Engine for a run that started now, comparing gaps
+// against reprovideInterval.
+func NewEngine(cfg Config, reprovideInterval time.Duration, sinks []Sink) *Engine {
+	now := time.Now()
+	return &Engine{
+		cfg:                cfg,
+		sinks:              sinks,
+		start:              now,
+		reprovideInterval:  reprovideInterval,
+		lastFired:          make(map[string]time.Time),
+		lastDaemonActivity: now,
+	}
+}
+
+// NoteDaemonActivity resets the silent-daemon timer (rule c). Call it
+// whenever a "sent provider record" line is observed.
+func (e *Engine) NoteDaemonActivity() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastDaemonActivity = time.Now()
+}
+
+// CheckCID evaluates the under-provided (a) and stale-gap (b) rules for
+// a single tracked CID.
+func (e *Engine) CheckCID(s CIDState) {
+	now := time.Now()
+	underProvidedAfter := time.Duration(e.cfg.UnderProvidedFactor * float64(e.reprovideInterval))
+	staleGapAfter := time.Duration(e.cfg.StaleGapFactor * float64(e.reprovideInterval))
+
+	if s.Count == 0 {
+		if age := now.Sub(e.start); age > underProvidedAfter {
+			e.fire(Alert{
+				Rule:    "under-provided",
+				CID:     s.CID,
+				Message: fmt.Sprintf("no provide record %s after start (threshold %s)", age.Round(time.Second), underProvidedAfter),
+				FiredAt: now,
+			})
+		}
+		return
+	}
+
+	if gap := now.Sub(s.LastSeen); gap > staleGapAfter {
+		e.fire(Alert{
+			Rule:    "stale-gap",
+			CID:     s.CID,
+			Message: fmt.Sprintf("%s since last provide record (threshold %s)", gap.Round(time.Second), staleGapAfter),
+			FiredAt: now,
+		})
+	}
+}
+
+// CheckDaemon evaluates the silent-daemon rule (c).
+func (e *Engine) CheckDaemon() {
+	e.mu.Lock()
+	quiet := time.Since(e.lastDaemonActivity)
+	e.mu.Unlock()
+
+	silentAfter := time.Duration(e.cfg.SilentDaemonFactor * float64(e.reprovideInterval))
+	if quiet > silentAfter {
+		e.fire(Alert{
+			Rule:    "silent-daemon",
+			Message: fmt.Sprintf("no provide record logged in %s (threshold %s)", quiet.Round(time.Second), silentAfter),
+			FiredAt: time.Now(),
+		})
+	}
+}
+
+// fire delivers a to every sink, unless it's within its rule+CID
+// cool-down window.
+func (e *Engine) fire(a Alert) {
+	key := a.Rule + "|" + a.CID
+	e.mu.Lock()
+	if last, ok := e.lastFired[key]; ok && a.FiredAt.Sub(last) < e.cfg.CoolDown {
+		e.mu.Unlock()
+		return
+	}
+	e.lastFired[key] = a.FiredAt
+	e.failed = true
+	e.mu.Unlock()
+
+	for _, sink := range e.sinks {
+		if err := sink.Fire(a); err != nil {
+			fmt.Fprintf(os.Stderr, "alerts: sink delivery failed: %v\n", err)
+		}
+	}
+}
+
+// Failed reports whether any alert has fired since the engine was
+// created, so callers running in CI can exit non-zero on SLO violations.
+func (e *Engine) Failed() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.failed
+}
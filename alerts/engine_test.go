@@ -0,0 +1,113 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeSink records every fired alert instead of delivering it anywhere.
+type fakeSink struct {
+	fired []Alert
+}
+
+func (f *fakeSink) Fire(a Alert) error {
+	f.fired = append(f.fired, a)
+	return nil
+}
+
+// newTestEngine builds an Engine directly (bypassing NewEngine) so tests
+// can control start/lastDaemonActivity precisely instead of sleeping.
+func newTestEngine(cfg Config, reprovideInterval time.Duration, sink *fakeSink) *Engine {
+	now := time.Now()
+	return &Engine{
+		cfg:                cfg,
+		sinks:              []Sink{sink},
+		start:              now,
+		reprovideInterval:  reprovideInterval,
+		lastFired:          make(map[string]time.Time),
+		lastDaemonActivity: now,
+	}
+}
+
+func TestCheckCIDUnderProvided(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{UnderProvidedFactor: 1.5, StaleGapFactor: 2, CoolDown: time.Minute}, time.Second, sink)
+	e.start = time.Now().Add(-2 * time.Second) // older than the 1.5s threshold
+
+	e.CheckCID(CIDState{CID: "cid1", Count: 0})
+
+	if len(sink.fired) != 1 || sink.fired[0].Rule != "under-provided" {
+		t.Fatalf("expected one under-provided alert, got %+v", sink.fired)
+	}
+	if !e.Failed() {
+		t.Fatal("expected Failed() to report true after an alert fired")
+	}
+}
+
+func TestCheckCIDNotYetUnderProvided(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{UnderProvidedFactor: 1.5, StaleGapFactor: 2, CoolDown: time.Minute}, time.Hour, sink)
+
+	e.CheckCID(CIDState{CID: "cid1", Count: 0})
+
+	if len(sink.fired) != 0 {
+		t.Fatalf("expected no alert before the threshold, got %+v", sink.fired)
+	}
+}
+
+func TestCheckCIDStaleGap(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{UnderProvidedFactor: 1.5, StaleGapFactor: 2, CoolDown: time.Minute}, time.Second, sink)
+
+	e.CheckCID(CIDState{CID: "cid1", Count: 1, LastSeen: time.Now().Add(-3 * time.Second)})
+
+	if len(sink.fired) != 1 || sink.fired[0].Rule != "stale-gap" {
+		t.Fatalf("expected one stale-gap alert, got %+v", sink.fired)
+	}
+}
+
+func TestFireDedupsWithinCoolDown(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{CoolDown: time.Hour}, time.Second, sink)
+	firstAt := time.Now()
+
+	e.fire(Alert{Rule: "x", CID: "cid1", FiredAt: firstAt})
+	e.fire(Alert{Rule: "x", CID: "cid1", FiredAt: firstAt.Add(time.Second)})
+
+	if len(sink.fired) != 1 {
+		t.Fatalf("expected the cool-down to dedup the second fire, got %d deliveries", len(sink.fired))
+	}
+}
+
+func TestFireAllowsAfterCoolDown(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{CoolDown: time.Second}, time.Second, sink)
+	firstAt := time.Now()
+
+	e.fire(Alert{Rule: "x", CID: "cid1", FiredAt: firstAt})
+	e.fire(Alert{Rule: "x", CID: "cid1", FiredAt: firstAt.Add(2 * time.Second)})
+
+	if len(sink.fired) != 2 {
+		t.Fatalf("expected both fires to be delivered once the cool-down elapsed, got %d", len(sink.fired))
+	}
+}
+
+// TestCheckDaemonScalesWithReprovideInterval guards the fix for alerts
+// false-firing on a healthy daemon whose reprovide interval is longer
+// than a fixed absolute silence threshold would allow.
+func TestCheckDaemonScalesWithReprovideInterval(t *testing.T) {
+	sink := &fakeSink{}
+	e := newTestEngine(Config{SilentDaemonFactor: 2, CoolDown: time.Minute}, 10*time.Minute, sink)
+
+	e.lastDaemonActivity = time.Now().Add(-15 * time.Minute) // under the 2x10m=20m threshold
+	e.CheckDaemon()
+	if len(sink.fired) != 0 {
+		t.Fatalf("expected no alert within the scaled threshold, got %+v", sink.fired)
+	}
+
+	e.lastDaemonActivity = time.Now().Add(-21 * time.Minute) // past the threshold
+	e.CheckDaemon()
+	if len(sink.fired) != 1 || sink.fired[0].Rule != "silent-daemon" {
+		t.Fatalf("expected one silent-daemon alert, got %+v", sink.fired)
+	}
+}
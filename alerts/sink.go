@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Alert is a single fired alert, delivered to every configured Sink.
+type Alert struct {
+	Rule    string    `json:"rule"`
+	CID     string    `json:"cid,omitempty"`
+	Message string    `json:"message"`
+	FiredAt time.Time `json:"firedAt"`
+}
+
+// Sink delivers alerts to some destination. Implementations must be safe
+// for concurrent use.
+type Sink interface {
+	Fire(a Alert) error
+}
+
+// StderrSink writes a one-line human-readable message per alert.
+type StderrSink struct{}
+
+func (StderrSink) Fire(a Alert) error {
+	_, err := fmt.Fprintf(os.Stderr, "[alert] rule=%s cid=%s: %s\n", a.Rule, a.CID, a.Message)
+	return err
+}
+
+// JSONLSink appends one JSON object per alert to a file, for ingestion by
+// CI log collectors.
+type JSONLSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewJSONLSink opens (creating if needed) the file at path for append.
+func NewJSONLSink(path string) (*JSONLSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open jsonl sink: %w", err)
+	}
+	return &JSONLSink{f: f}, nil
+}
+
+func (s *JSONLSink) Fire(a Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.f).Encode(a)
+}
+
+func (s *JSONLSink) Close() error {
+	return s.f.Close()
+}
+
+// WebhookSink POSTs each alert as a JSON body to an HTTP endpoint.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink returns a WebhookSink with a bounded request timeout.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *WebhookSink) Fire(a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("marshal alert: %w", err)
+	}
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: unexpected status %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// BuildSinks constructs the Sinks described by cfg, opening any file or
+// HTTP resources they need.
+func BuildSinks(cfg SinksConfig) ([]Sink, error) {
+	var sinks []Sink
+	if cfg.Stderr {
+		sinks = append(sinks, StderrSink{})
+	}
+	if cfg.JSONLPath != "" {
+		s, err := NewJSONLSink(cfg.JSONLPath)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(cfg.WebhookURL))
+	}
+	return sinks, nil
+}
@@ -1,41 +1,89 @@
+// Command bruteforce generates libp2p identities with a vanity Kademlia
+// ID, built on the vanitykad package.
 package main
 
 import (
-	"crypto/ed25519"
-	"crypto/rand"
-	"crypto/sha256"
+	"context"
 	"encoding/base64"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/mr-tron/base58"
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/guillaumemichel/kubo-provider-test-harness/vanitykad"
 )
 
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s generate --prefix-bits N [--workers N] [--checkpoint FILE] [--resume-from FILE]\n", os.Args[0])
+}
+
 func main() {
-	for i := 0; ; i++ {
-		pub, priv, err := ed25519.GenerateKey(rand.Reader)
-		if err != nil {
-			panic(err)
-		}
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
 
-		// Marshal public key to libp2p protobuf: Type=Ed25519(1), Data=32-byte pubkey
-		marshalledPub := append([]byte{0x08, 0x01, 0x12, 0x20}, pub...)
+	switch os.Args[1] {
+	case "generate":
+		runGenerate(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
 
-		// Peer ID = identity multihash (code=0x00, length=36) of marshalled pubkey
-		peerIDBytes := append([]byte{0x00, 0x24}, marshalledPub...)
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	prefixBits := fs.Int("prefix-bits", 8, "number of leading zero bits required in the Kademlia ID")
+	workers := fs.Int("workers", 0, "number of worker goroutines (0 = runtime.NumCPU())")
+	checkpointPath := fs.String("checkpoint", "", "periodically save attempt progress to this file")
+	resumeFrom := fs.String("resume-from", "", "resume the attempt count from a checkpoint file previously written by --checkpoint")
+	fs.Parse(args)
 
-		// Kademlia ID = SHA256(peer_id_bytes)
-		kadID := sha256.Sum256(peerIDBytes)
+	var resume *vanitykad.Checkpoint
+	if *resumeFrom != "" {
+		c, err := vanitykad.LoadCheckpoint(*resumeFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "resume-from: %v\n", err)
+			os.Exit(1)
+		}
+		resume = &c
+	}
 
-		if kadID[0] == 0x00 {
-			// Marshal private key: Type=Ed25519(1), Data=seed(32)+pubkey(32)=64 bytes
-			privData := append(priv.Seed(), pub...)
-			marshalledPriv := append([]byte{0x08, 0x01, 0x12, 0x40}, privData...)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		fmt.Fprintln(os.Stderr, "\ninterrupted, stopping…")
+		cancel()
+	}()
 
-			fmt.Printf("Found after %d attempts!\n", i+1)
-			fmt.Printf("PeerID:  %s\n", base58.Encode(peerIDBytes))
-			fmt.Printf("PrivKey: %s\n", base64.StdEncoding.EncodeToString(marshalledPriv))
-			fmt.Printf("KadID:   %x\n", kadID)
-			return
+	id, priv, stats, err := vanitykad.Generate(ctx, *prefixBits, *workers, resume, func(s vanitykad.Stats) {
+		fmt.Fprintf(os.Stderr, "\r  %d attempts, %.0f/s, ETA %s   ", s.Attempts, s.Rate, s.ETA.Round(time.Second))
+		if *checkpointPath != "" {
+			if err := vanitykad.SaveCheckpoint(*checkpointPath, vanitykad.Checkpoint{Attempts: s.Attempts}); err != nil {
+				fmt.Fprintf(os.Stderr, "\ncheckpoint: %v\n", err)
+			}
 		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\ngenerate: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "\nFound after %d attempts in %s!\n", stats.Attempts, stats.Elapsed.Round(time.Second))
+
+	// Emit the marshalled protobuf pub/priv exactly as Kubo's config expects.
+	privBytes, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "marshal private key: %v\n", err)
+		os.Exit(1)
 	}
+	fmt.Printf("PeerID:  %s\n", id.String())
+	fmt.Printf("PrivKey: %s\n", base64.StdEncoding.EncodeToString(privBytes))
 }
@@ -0,0 +1,58 @@
+package harness
+
+import "github.com/guillaumemichel/kubo-provider-test-harness/providermetrics"
+
+// jaccard returns the Jaccard similarity of two prefix sets, defined as
+// 1 when both are empty (nothing to disagree on).
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	seen := make(map[string]struct{}, len(a)+len(b))
+	inter := 0
+	for p := range a {
+		seen[p] = struct{}{}
+		if _, ok := b[p]; ok {
+			inter++
+		}
+	}
+	for p := range b {
+		seen[p] = struct{}{}
+	}
+	return float64(inter) / float64(len(seen))
+}
+
+// computeFanOutOverlap averages the per-CID Jaccard overlap of provide
+// record prefixes between every pair of nodes, skipping CIDs neither
+// node advertised.
+func computeFanOutOverlap(nodes []*runningNode, tracked map[string]string) map[string]map[string]float64 {
+	overlap := make(map[string]map[string]float64)
+	snapshots := make([]map[string]providermetrics.State, len(nodes))
+	for i, n := range nodes {
+		snapshots[i] = n.metrics.Snapshot()
+	}
+
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			var sum float64
+			var n int
+			for hash := range tracked {
+				pa, pb := snapshots[i][hash].Prefixes, snapshots[j][hash].Prefixes
+				if len(pa) == 0 && len(pb) == 0 {
+					continue
+				}
+				sum += jaccard(pa, pb)
+				n++
+			}
+			if n == 0 {
+				continue
+			}
+			a, b := nodes[i].cfg.Strategy.Name, nodes[j].cfg.Strategy.Name
+			if overlap[a] == nil {
+				overlap[a] = make(map[string]float64)
+			}
+			overlap[a][b] = sum / float64(n)
+		}
+	}
+	return overlap
+}
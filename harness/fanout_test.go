@@ -0,0 +1,35 @@
+package harness
+
+import "testing"
+
+func TestJaccardBothEmpty(t *testing.T) {
+	if got := jaccard(nil, nil); got != 1 {
+		t.Fatalf("jaccard(nil, nil) = %v, want 1", got)
+	}
+}
+
+func TestJaccardDisjoint(t *testing.T) {
+	a := map[string]struct{}{"0000": {}}
+	b := map[string]struct{}{"1111": {}}
+	if got := jaccard(a, b); got != 0 {
+		t.Fatalf("jaccard(disjoint) = %v, want 0", got)
+	}
+}
+
+func TestJaccardIdentical(t *testing.T) {
+	a := map[string]struct{}{"0000": {}, "1111": {}}
+	b := map[string]struct{}{"0000": {}, "1111": {}}
+	if got := jaccard(a, b); got != 1 {
+		t.Fatalf("jaccard(identical) = %v, want 1", got)
+	}
+}
+
+func TestJaccardPartialOverlap(t *testing.T) {
+	a := map[string]struct{}{"0000": {}, "1111": {}}
+	b := map[string]struct{}{"0000": {}, "2222": {}}
+	// intersection {0000} = 1, union {0000,1111,2222} = 3.
+	const want = 1.0 / 3.0
+	if got := jaccard(a, b); got != want {
+		t.Fatalf("jaccard(partial) = %v, want %v", got, want)
+	}
+}
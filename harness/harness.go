@@ -0,0 +1,240 @@
+// Package harness stands up N Kubo nodes concurrently, each configured
+// with a different Provide strategy, runs the same corpus of CIDs
+// through all of them, and produces a comparative report. The repo's
+// original single-node smoke test is the degenerate N=1 case.
+package harness
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/guillaumemichel/kubo-provider-test-harness/alerts"
+	"github.com/guillaumemichel/kubo-provider-test-harness/providermetrics"
+)
+
+// StrategyConfig describes the Provide-related configuration knobs this
+// package compares across nodes.
+type StrategyConfig struct {
+	Name                 string // label used in reports, e.g. "pinned+sweep"
+	ProvideStrategy      string // Provide.Strategy: "all" | "pinned" | "roots" | "mfs" ...
+	SweepEnabled         bool   // Provide.DHT.SweepEnabled
+	AcceleratedDHTClient bool   // Routing.AcceleratedDHTClient
+	ReprovideInterval    time.Duration
+}
+
+// NodeConfig is everything Run needs to stand up one Kubo node.
+type NodeConfig struct {
+	Strategy    StrategyConfig
+	RepoDir     string
+	SwarmPort   int
+	APIPort     int
+	GatewayPort int
+	PeerID      string // from vanitykad; empty lets `ipfs init` generate one
+	PrivKey     string
+	// Alerts configures this node's alerts.Engine. The zero value means
+	// alerts.DefaultConfig().
+	Alerts alerts.Config
+
+	// VerifyInterval, if positive, runs an independent DHT lookup
+	// (see the verifier package) against every tracked CID on this
+	// cadence. Zero disables active verification.
+	VerifyInterval       time.Duration
+	VerifyRequestedPeers int           // default 20 if unset and VerifyInterval > 0
+	VerifyTimeout        time.Duration // default 30s if unset and VerifyInterval > 0
+}
+
+// Config is a full multi-node comparison run.
+type Config struct {
+	Nodes []NodeConfig
+	// GeneratedDir is added (offline, identically) to every node's repo
+	// before its daemon starts.
+	GeneratedDir string
+	// StatusInterval controls how often Run dumps each node's console
+	// status and checks alerts while waiting for ctx to complete. Zero
+	// means one minute, matching the original single-node cadence.
+	StatusInterval time.Duration
+	// MetricsAddr, if set, serves every node's Prometheus metrics
+	// (labeled by strategy name) at MetricsAddr + "/metrics".
+	MetricsAddr string
+}
+
+// NodeReport is one node's contribution to a comparative Report.
+type NodeReport struct {
+	Strategy          StrategyConfig
+	BandwidthInBytes  uint64
+	BandwidthOutBytes uint64
+	// ReprovideJitter is the standard deviation of the observed gaps
+	// between consecutive provide records for the same CID.
+	ReprovideJitter time.Duration
+}
+
+// CIDReport is one tracked CID's contribution to a comparative Report.
+type CIDReport struct {
+	CID string
+	// TimeToFirstProvide is keyed by strategy name; a strategy absent
+	// from the map never advertised this CID during the run.
+	TimeToFirstProvide map[string]time.Duration
+}
+
+// Report is the comparative output of a Run across all configured
+// strategies.
+type Report struct {
+	Nodes []NodeReport
+	CIDs  []CIDReport
+	// FanOutOverlap approximates provide-record fan-out overlap between
+	// two strategies as the average Jaccard similarity, across shared
+	// CIDs, of the sets of DHT keyspace prefixes their provide records
+	// were logged against. The daemon doesn't log individual peer IDs
+	// on these lines, so the prefix is the closest available proxy for
+	// "which peer group a record reached".
+	FanOutOverlap map[string]map[string]float64
+}
+
+// Run stands up one Kubo node per cfg.Nodes entry, seeds every node with
+// the same corpus, and watches provide activity until ctx is done, then
+// returns a comparative Report. A single-entry cfg.Nodes is the
+// degenerate N=1 smoke test this harness started as.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if len(cfg.Nodes) == 0 {
+		return nil, fmt.Errorf("harness: at least one node is required")
+	}
+
+	nodes := make([]*runningNode, len(cfg.Nodes))
+	var tracked map[string]string
+	start := time.Now()
+	metricsReg := prometheus.NewRegistry()
+
+	for i, nc := range cfg.Nodes {
+		if err := initNode(nc); err != nil {
+			return nil, err
+		}
+
+		t, err := addCorpus(nc, cfg.GeneratedDir)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: add corpus: %w", nc.Strategy.Name, err)
+		}
+		if tracked == nil {
+			tracked = t
+		}
+
+		reg := prometheus.WrapRegistererWith(prometheus.Labels{"strategy": nc.Strategy.Name}, metricsReg)
+		n, err := newRunningNode(nc, t, reg)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", nc.Strategy.Name, err)
+		}
+		if err := n.start(); err != nil {
+			return nil, fmt.Errorf("node %s: %w", nc.Strategy.Name, err)
+		}
+		nodes[i] = n
+	}
+	defer func() {
+		for _, n := range nodes {
+			n.stop()
+		}
+	}()
+
+	if cfg.MetricsAddr != "" {
+		go func() {
+			if err := providermetrics.ServeMetrics(cfg.MetricsAddr, metricsReg); err != nil {
+				fmt.Printf("metrics server: %v\n", err)
+			}
+		}()
+	}
+
+	for _, n := range nodes {
+		n := n
+		go func() {
+			if err := n.runVerifier(ctx); err != nil {
+				fmt.Printf("node %s: verifier: %v\n", n.cfg.Strategy.Name, err)
+			}
+		}()
+	}
+
+	statusInterval := cfg.StatusInterval
+	if statusInterval <= 0 {
+		statusInterval = time.Minute
+	}
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+
+runLoop:
+	for {
+		select {
+		case <-ctx.Done():
+			break runLoop
+		case <-ticker.C:
+			for _, n := range nodes {
+				if n.stopped {
+					continue
+				}
+				n.metrics.DumpStatus(os.Stdout, time.Since(start), n.cfg.Strategy.ReprovideInterval)
+				n.alerts.CheckDaemon()
+				for _, s := range n.metrics.Snapshot() {
+					n.alerts.CheckCID(alerts.CIDState{CID: s.CID, Count: s.Count, LastSeen: s.LastSeen})
+				}
+				if n.alerts.Failed() {
+					// Only the offending node is torn down: one
+					// strategy tripping an SLO shouldn't cost the
+					// whole comparison its other, healthy nodes.
+					fmt.Printf("node %s: alerts fired, stopping this node (see sinks above for detail)\n", n.cfg.Strategy.Name)
+					n.stop()
+				}
+			}
+		}
+	}
+
+	report := &Report{}
+	for _, n := range nodes {
+		in, out, err := n.bandwidth()
+		if err != nil {
+			fmt.Printf("node %s: stats bw: %v\n", n.cfg.Strategy.Name, err)
+		}
+		report.Nodes = append(report.Nodes, NodeReport{
+			Strategy:          n.cfg.Strategy,
+			BandwidthInBytes:  in,
+			BandwidthOutBytes: out,
+			ReprovideJitter:   jitter(n.reprovideGaps()),
+		})
+	}
+
+	for hash, cidStr := range tracked {
+		cr := CIDReport{CID: cidStr, TimeToFirstProvide: make(map[string]time.Duration)}
+		for _, n := range nodes {
+			if s, ok := n.metrics.Snapshot()[hash]; ok && s.Count > 0 {
+				cr.TimeToFirstProvide[n.cfg.Strategy.Name] = s.FirstSeen.Sub(start)
+			}
+		}
+		report.CIDs = append(report.CIDs, cr)
+	}
+	sort.Slice(report.CIDs, func(i, j int) bool { return report.CIDs[i].CID < report.CIDs[j].CID })
+
+	report.FanOutOverlap = computeFanOutOverlap(nodes, tracked)
+
+	return report, nil
+}
+
+// jitter returns the standard deviation of gaps, as a simple proxy for
+// reprovide jitter across a strategy.
+func jitter(gaps []time.Duration) time.Duration {
+	if len(gaps) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, g := range gaps {
+		sum += g.Seconds()
+	}
+	mean := sum / float64(len(gaps))
+	var variance float64
+	for _, g := range gaps {
+		d := g.Seconds() - mean
+		variance += d * d
+	}
+	variance /= float64(len(gaps))
+	return time.Duration(math.Sqrt(variance) * float64(time.Second))
+}
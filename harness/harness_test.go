@@ -0,0 +1,36 @@
+package harness
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterFewerThanTwoGaps(t *testing.T) {
+	if got := jitter(nil); got != 0 {
+		t.Fatalf("jitter(nil) = %v, want 0", got)
+	}
+	if got := jitter([]time.Duration{5 * time.Second}); got != 0 {
+		t.Fatalf("jitter(one gap) = %v, want 0", got)
+	}
+}
+
+func TestJitterConstantGapsIsZero(t *testing.T) {
+	gaps := []time.Duration{10 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	if got := jitter(gaps); got != 0 {
+		t.Fatalf("jitter(constant gaps) = %v, want 0", got)
+	}
+}
+
+func TestJitterVariesWithSpread(t *testing.T) {
+	gaps := []time.Duration{8 * time.Minute, 10 * time.Minute, 12 * time.Minute}
+	got := jitter(gaps)
+	if got <= 0 {
+		t.Fatalf("jitter(spread gaps) = %v, want > 0", got)
+	}
+	// Population stddev of {8,10,12} minutes is sqrt(8/3) minutes ~= 1.633m.
+	want := time.Duration(1.633 * float64(time.Minute))
+	const tolerance = 2 * time.Second
+	if d := got - want; d < -tolerance || d > tolerance {
+		t.Fatalf("jitter(spread gaps) = %v, want ~%v", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package harness
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/guillaumemichel/kubo-provider-test-harness/alerts"
+	"github.com/guillaumemichel/kubo-provider-test-harness/providermetrics"
+	"github.com/guillaumemichel/kubo-provider-test-harness/verifier"
+)
+
+// runningNode is one live Kubo daemon under comparison, with its own
+// repo dir, ports, provide-log collector, and alerts engine.
+type runningNode struct {
+	cfg     NodeConfig
+	tracked map[string]string
+	metrics *providermetrics.Collector
+	alerts  *alerts.Engine
+
+	cmd     *exec.Cmd
+	stopped bool
+}
+
+func newRunningNode(cfg NodeConfig, tracked map[string]string, reg prometheus.Registerer) (*runningNode, error) {
+	alertsCfg := cfg.Alerts
+	if (alertsCfg == alerts.Config{}) {
+		alertsCfg = alerts.DefaultConfig()
+	}
+	sinks, err := alerts.BuildSinks(alertsCfg.Sinks)
+	if err != nil {
+		return nil, fmt.Errorf("build alert sinks: %w", err)
+	}
+
+	return &runningNode{
+		cfg:     cfg,
+		tracked: tracked,
+		metrics: providermetrics.New(tracked, reg),
+		alerts:  alerts.NewEngine(alertsCfg, cfg.Strategy.ReprovideInterval, sinks),
+	}, nil
+}
+
+func ipfsEnv(ipfsPath string) []string {
+	return append(os.Environ(), "IPFS_PATH="+ipfsPath)
+}
+
+func runIpfs(ipfsPath string, args ...string) (string, error) {
+	cmd := exec.Command("ipfs", args...)
+	cmd.Env = ipfsEnv(ipfsPath)
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%s: %s", err, ee.Stderr)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// initNode creates a fresh repo for nc and applies the strategy-specific
+// configuration this package compares.
+func initNode(nc NodeConfig) error {
+	os.RemoveAll(nc.RepoDir)
+	if _, err := runIpfs(nc.RepoDir, "init", "--empty-repo"); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+
+	intervalStr := fmt.Sprintf("%dm", int(nc.Strategy.ReprovideInterval.Minutes()))
+	cfgCmds := [][]string{
+		{"config", "--json", "Provide.DHT.Interval", fmt.Sprintf(`"%s"`, intervalStr)},
+		{"config", "Provide.Strategy", nc.Strategy.ProvideStrategy},
+		{"config", "--json", "Provide.DHT.SweepEnabled", strconv.FormatBool(nc.Strategy.SweepEnabled)},
+		{"config", "--json", "Routing.AcceleratedDHTClient", strconv.FormatBool(nc.Strategy.AcceleratedDHTClient)},
+		{"config", "Addresses.API", fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", nc.APIPort)},
+		{"config", "Addresses.Gateway", fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", nc.GatewayPort)},
+		{"config", "--json", "Addresses.Swarm", fmt.Sprintf(
+			`["/ip4/0.0.0.0/tcp/%d","/ip6/::/tcp/%d","/ip4/0.0.0.0/udp/%d/quic-v1","/ip6/::/udp/%d/quic-v1"]`,
+			nc.SwarmPort, nc.SwarmPort, nc.SwarmPort, nc.SwarmPort)},
+		{"config", "Plugins.Plugins.telemetry.Config.Mode", "off"},
+	}
+	for _, args := range cfgCmds {
+		if _, err := runIpfs(nc.RepoDir, args...); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+		}
+	}
+
+	if nc.PeerID != "" {
+		if err := setIdentity(nc.RepoDir, nc.PeerID, nc.PrivKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setIdentity edits the config file directly, mirroring the original
+// single-node flow: the API rejects private key changes.
+func setIdentity(ipfsPath, peerID, privKey string) error {
+	configPath := filepath.Join(ipfsPath, "config")
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal(configData, &cfg); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	cfg["Identity"] = map[string]string{"PeerID": peerID, "PrivKey": privKey}
+	configData, _ = json.MarshalIndent(cfg, "", "  ")
+	return os.WriteFile(configPath, configData, 0o600)
+}
+
+// emptyDirCID is the always-pinned empty MFS root's CID, tracked
+// alongside whatever addCorpus adds so reports can confirm it also gets
+// reprovided under the pinned strategy, mirroring the original
+// single-node flow.
+const emptyDirCID = "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+
+// addCorpus adds dir offline to the node's repo and returns the tracked
+// multihash -> CID map, mirroring the original single-node flow.
+func addCorpus(nc NodeConfig, dir string) (map[string]string, error) {
+	out, err := runIpfs(nc.RepoDir, "add", "-r", "-q", "--cid-version=1", "--raw-leaves", dir)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	tracked := make(map[string]string, len(lines)+1)
+	for _, s := range append(lines, emptyDirCID) {
+		c, err := cid.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad CID %s: %w", s, err)
+		}
+		tracked[string(c.Hash())] = s
+	}
+	return tracked, nil
+}
+
+func (n *runningNode) start() error {
+	n.cmd = exec.Command("ipfs", "daemon")
+	n.cmd.Env = append(ipfsEnv(n.cfg.RepoDir), "GOLOG_LOG_LEVEL=dht=debug,dht/provider=debug")
+	stdoutPipe, _ := n.cmd.StdoutPipe()
+	stderrPipe, _ := n.cmd.StderrPipe()
+	if err := n.cmd.Start(); err != nil {
+		return fmt.Errorf("daemon start: %w", err)
+	}
+
+	stdoutSc := bufio.NewScanner(stdoutPipe)
+	ready := false
+	for stdoutSc.Scan() {
+		if strings.Contains(stdoutSc.Text(), "Daemon is ready") {
+			ready = true
+			break
+		}
+	}
+	if !ready {
+		return fmt.Errorf("daemon never became ready")
+	}
+	go func() {
+		for stdoutSc.Scan() {
+		}
+	}()
+
+	go func() {
+		sc := bufio.NewScanner(stderrPipe)
+		sc.Buffer(make([]byte, 0, 1<<20), 1<<20)
+		for sc.Scan() {
+			if n.metrics.ObserveLine(sc.Text()) {
+				n.alerts.NoteDaemonActivity()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// verifyConcurrency bounds how many tracked CIDs runVerifier looks up
+// at once, so one tick's lookups fan out instead of serializing behind
+// each other and falling behind VerifyInterval as the tracked set grows.
+const verifyConcurrency = 8
+
+// runVerifier periodically checks every tracked CID against the live
+// DHT via an independent client, merging results into n.metrics so the
+// status dump can distinguish "daemon claims provided" from "network
+// actually serves it". It returns immediately if n.cfg.VerifyInterval
+// is not positive, and runs until ctx is done otherwise.
+func (n *runningNode) runVerifier(ctx context.Context) error {
+	if n.cfg.VerifyInterval <= 0 {
+		return nil
+	}
+	requested := n.cfg.VerifyRequestedPeers
+	if requested <= 0 {
+		requested = 20
+	}
+	timeout := n.cfg.VerifyTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	v, err := verifier.New(ctx, requested, timeout)
+	if err != nil {
+		return fmt.Errorf("start verifier: %w", err)
+	}
+	defer v.Close()
+
+	ticker := time.NewTicker(n.cfg.VerifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			sem := make(chan struct{}, verifyConcurrency)
+			var wg sync.WaitGroup
+			for hash, cidStr := range n.tracked {
+				c, err := cid.Decode(cidStr)
+				if err != nil {
+					continue
+				}
+				hash, c := hash, c
+				wg.Add(1)
+				sem <- struct{}{}
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+					res := v.Verify(ctx, c)
+					n.metrics.RecordVerification(hash, providermetrics.VerificationResult{
+						Verified: res.Verified(),
+						Latency:  res.Latency,
+					})
+				}()
+			}
+			wg.Wait()
+		}
+	}
+}
+
+func (n *runningNode) stop() {
+	if n.stopped || n.cmd == nil || n.cmd.Process == nil {
+		return
+	}
+	n.stopped = true
+	n.cmd.Process.Signal(syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() { n.cmd.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(15 * time.Second):
+		n.cmd.Process.Kill()
+		n.cmd.Wait()
+	}
+}
+
+// reprovideGaps flattens the per-CID inter-provide gaps this node's
+// collector observed, for the comparative report's jitter calculation.
+func (n *runningNode) reprovideGaps() []time.Duration {
+	var gaps []time.Duration
+	for _, s := range n.metrics.Snapshot() {
+		gaps = append(gaps, s.Gaps...)
+	}
+	return gaps
+}
+
+// bandwidth parses `ipfs stats bw` totals for the comparative report.
+func (n *runningNode) bandwidth() (in, out uint64, err error) {
+	raw, err := runIpfs(n.cfg.RepoDir, "stats", "bw")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "TotalIn:"):
+			in = parseBwValue(strings.TrimPrefix(line, "TotalIn:"))
+		case strings.HasPrefix(line, "TotalOut:"):
+			out = parseBwValue(strings.TrimPrefix(line, "TotalOut:"))
+		}
+	}
+	return in, out, nil
+}
+
+// parseBwValue parses the human-readable size `ipfs stats bw` prints
+// (e.g. "1.2 kB", "512 B") into bytes. Unrecognized units return 0,
+// since this is a best-effort comparative signal, not a metering source
+// of truth.
+func parseBwValue(s string) uint64 {
+	fields := strings.Fields(strings.TrimSpace(s))
+	if len(fields) != 2 {
+		return 0
+	}
+	val, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	mult, ok := map[string]float64{"B": 1, "kB": 1e3, "MB": 1e6, "GB": 1e9}[fields[1]]
+	if !ok {
+		return 0
+	}
+	return uint64(val * mult)
+}
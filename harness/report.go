@@ -0,0 +1,45 @@
+package harness
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// String renders the comparative report as the same kind of
+// human-readable block the single-node harness has always printed for
+// its status dump.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", strings.Repeat("=", 60))
+	fmt.Fprintf(&b, "STRATEGY COMPARISON (%d nodes, %d CIDs)\n", len(r.Nodes), len(r.CIDs))
+	for _, nr := range r.Nodes {
+		fmt.Fprintf(&b, "  %-20s bw in=%d out=%d reprovide-jitter=%s\n",
+			nr.Strategy.Name, nr.BandwidthInBytes, nr.BandwidthOutBytes, nr.ReprovideJitter)
+	}
+
+	if len(r.FanOutOverlap) > 0 {
+		fmt.Fprintf(&b, "  fan-out overlap (Jaccard over provide-record prefixes):\n")
+		var pairs []string
+		for a, bs := range r.FanOutOverlap {
+			for strat := range bs {
+				pairs = append(pairs, a+"/"+strat)
+			}
+		}
+		sort.Strings(pairs)
+		for _, p := range pairs {
+			parts := strings.SplitN(p, "/", 2)
+			fmt.Fprintf(&b, "    %s: %.2f\n", p, r.FanOutOverlap[parts[0]][parts[1]])
+		}
+	}
+
+	var neverProvided int
+	for _, cr := range r.CIDs {
+		if len(cr.TimeToFirstProvide) == 0 {
+			neverProvided++
+		}
+	}
+	fmt.Fprintf(&b, "  CIDs never provided by any strategy: %d/%d\n", neverProvided, len(r.CIDs))
+	fmt.Fprintf(&b, "%s\n", strings.Repeat("=", 60))
+	return b.String()
+}
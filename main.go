@@ -1,244 +1,191 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/base64"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"strings"
-	"sync"
 	"syscall"
 	"time"
 
-	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/guillaumemichel/kubo-provider-test-harness/alerts"
+	"github.com/guillaumemichel/kubo-provider-test-harness/harness"
+	"github.com/guillaumemichel/kubo-provider-test-harness/vanitykad"
 )
 
 const (
 	peerID            = "12D3KooWPGUHammYxStT9qMmKidZBUChutLLLXjmumoXhQRofhNp"
 	privKey           = "CAESQCDaw5OT66egT4ShrkA7WoFY6FT7NSGPvOlG3Phh3qGZx9fy2KzoCFA2VkLQUtLIiv4rbiDmpff4wlwUwolvgiE="
-	emptyDirCID       = "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
 	reprovideInterval = 10 * time.Minute
+
+	// basePort is the first node's swarm port; its API and gateway ports
+	// sit at fixed offsets above it. Each additional node in a
+	// multi-strategy run shifts all three ports by 10.
+	basePort = 4401
 )
 
-var ipfsPath string
+var (
+	metricsAddr      = flag.String("metrics-addr", ":9400", "address to serve the Prometheus /metrics endpoint on")
+	alertsConfigPath = flag.String("alerts-config", "", "path to a YAML alerts config (defaults to alerts.DefaultConfig if unset)")
+	verifyInterval   = flag.Duration("verify-interval", 0, "interval at which to independently verify tracked CIDs against the live DHT (0 disables)")
+	verifyPeers      = flag.Int("verify-peers", 20, "maximum number of providers the DHT lookup asks for when verifying a CID")
+	strategiesConfig = flag.String("strategies-config", "", "path to a YAML strategies config comparing N nodes (defaults to the single pinned-strategy node if unset)")
+)
 
-func ipfsEnv() []string {
-	return append(os.Environ(), "IPFS_PATH="+ipfsPath)
+// strategySpec is one entry of a YAML strategies config, letting a CLI
+// user compare more than the single hardcoded strategy the original
+// smoke test shipped with.
+type strategySpec struct {
+	Name                 string        `yaml:"name"`
+	ProvideStrategy      string        `yaml:"provideStrategy"`
+	SweepEnabled         bool          `yaml:"sweepEnabled"`
+	AcceleratedDHTClient bool          `yaml:"acceleratedDHTClient"`
+	ReprovideInterval    time.Duration `yaml:"reprovideInterval"`
+	// VanityPrefixBits, if > 0, generates a fresh peer identity for this
+	// node via vanitykad.Generate instead of reusing the repo's fixed
+	// default identity.
+	VanityPrefixBits int `yaml:"vanityPrefixBits"`
 }
 
-func ipfs(args ...string) (string, error) {
-	cmd := exec.Command("ipfs", args...)
-	cmd.Env = ipfsEnv()
-	out, err := cmd.Output()
-	if err != nil {
-		if ee, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("%s: %s", err, ee.Stderr)
-		}
-		return "", err
+// strategiesFile is the root of a YAML strategies config.
+type strategiesFile struct {
+	Strategies []strategySpec `yaml:"strategies"`
+}
+
+// defaultStrategies mirrors the single pinned-strategy node the original
+// single-node smoke test always ran.
+func defaultStrategies() []strategySpec {
+	return []strategySpec{
+		{Name: "pinned", ProvideStrategy: "pinned", ReprovideInterval: reprovideInterval},
 	}
-	return string(out), nil
 }
 
-func mustIpfs(args ...string) string {
-	out, err := ipfs(args...)
+// loadStrategies reads a YAML strategies config from path.
+func loadStrategies(path string) ([]strategySpec, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ipfs %s: %v\n", strings.Join(args, " "), err)
-		os.Exit(1)
+		return nil, fmt.Errorf("read strategies config: %w", err)
+	}
+	var f strategiesFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse strategies config: %w", err)
 	}
-	return out
+	if len(f.Strategies) == 0 {
+		return nil, fmt.Errorf("strategies config has no strategies")
+	}
+	return f.Strategies, nil
 }
 
-type entry struct {
-	cid   string
-	count int
+// identityFor returns the PeerID/PrivKey for node i: a freshly generated
+// vanity identity if spec asks for one, the repo's fixed default identity
+// for the first node (matching the original single-node behavior), or
+// empty to let `ipfs init` generate one.
+func identityFor(ctx context.Context, i int, spec strategySpec) (id, priv string, err error) {
+	if spec.VanityPrefixBits > 0 {
+		fmt.Printf("generating vanity identity for %q (%d prefix bits)...\n", spec.Name, spec.VanityPrefixBits)
+		pid, pk, stats, err := vanitykad.Generate(ctx, spec.VanityPrefixBits, 0, nil, nil)
+		if err != nil {
+			return "", "", fmt.Errorf("generate identity: %w", err)
+		}
+		privBytes, err := crypto.MarshalPrivateKey(pk)
+		if err != nil {
+			return "", "", fmt.Errorf("marshal identity: %w", err)
+		}
+		fmt.Printf("  found after %d attempts in %s\n", stats.Attempts, stats.Elapsed.Round(time.Second))
+		return pid.String(), base64.StdEncoding.EncodeToString(privBytes), nil
+	}
+	if i == 0 {
+		return peerID, privKey, nil
+	}
+	return "", "", nil
 }
 
+// run stands up one Kubo node per configured strategy and produces a
+// comparative report. With no --strategies-config, this is the
+// degenerate N=1 case that's always been this repo's smoke test.
 func run() error {
 	dir, _ := os.Getwd()
-	ipfsPath = filepath.Join(dir, ".ipfs")
-	generatedDir := filepath.Join(dir, "generated_files")
-
-	// 1. Init + configure
-	os.RemoveAll(ipfsPath)
-	fmt.Println("Initializing IPFS node…")
-	mustIpfs("init", "--empty-repo")
-	intervalStr := fmt.Sprintf("%dm", int(reprovideInterval.Minutes()))
-	mustIpfs("config", "--json", "Provide.DHT.Interval", fmt.Sprintf(`"%s"`, intervalStr))
-	mustIpfs("config", "Provide.Strategy", "pinned")
-	mustIpfs("config", "Addresses.API", "/ip4/127.0.0.1/tcp/5401")
-	mustIpfs("config", "Addresses.Gateway", "/ip4/127.0.0.1/tcp/8480")
-	mustIpfs("config", "--json", "Addresses.Swarm",
-		`["/ip4/0.0.0.0/tcp/4401","/ip6/::/tcp/4401","/ip4/0.0.0.0/udp/4401/quic-v1","/ip6/::/udp/4401/quic-v1"]`)
-	mustIpfs("config", "Plugins.Plugins.telemetry.Config.Mode", "off")
-
-	// Identity must be set by editing config file directly (API blocks private key changes)
-	configPath := filepath.Join(ipfsPath, "config")
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		return fmt.Errorf("read config: %w", err)
-	}
-	var cfg map[string]any
-	if err := json.Unmarshal(configData, &cfg); err != nil {
-		return fmt.Errorf("parse config: %w", err)
-	}
-	cfg["Identity"] = map[string]string{"PeerID": peerID, "PrivKey": privKey}
-	configData, _ = json.MarshalIndent(cfg, "", "  ")
-	if err := os.WriteFile(configPath, configData, 0o600); err != nil {
-		return fmt.Errorf("write config: %w", err)
-	}
-	fmt.Printf("Configured: interval=%s, strategy=pinned, ports=5401/4401/8480\n", intervalStr)
-
-	// 2. Add files offline
-	fmt.Println("\nAdding generated_files/ recursively…")
-	out := mustIpfs("add", "-r", "-q", "--cid-version=1", "--raw-leaves", generatedDir)
-	lines := strings.Split(strings.TrimSpace(out), "\n")
-	fileCIDs := lines[:len(lines)-1]
-	dirCID := lines[len(lines)-1]
-
-	// Build tracking map: multihash bytes (as string key) -> entry
-	tracked := make(map[string]*entry)
-	for _, s := range append(fileCIDs, dirCID, emptyDirCID) {
-		c, err := cid.Decode(s)
+
+	alertsCfg := alerts.DefaultConfig()
+	if *alertsConfigPath != "" {
+		var err error
+		alertsCfg, err = alerts.LoadConfig(*alertsConfigPath)
 		if err != nil {
-			return fmt.Errorf("bad CID %s: %w", s, err)
+			return fmt.Errorf("load alerts config: %w", err)
 		}
-		tracked[string(c.Hash())] = &entry{cid: s}
 	}
-	total := len(tracked)
-	fmt.Printf("Tracking %d CIDs\n", total)
-
-	// 3. Start daemon
-	fmt.Println("\nStarting daemon…")
-	daemon := exec.Command("ipfs", "daemon")
-	daemon.Env = append(ipfsEnv(), "GOLOG_LOG_LEVEL=dht=debug,dht/provider=debug")
-	stdoutPipe, _ := daemon.StdoutPipe()
-	stderrPipe, _ := daemon.StderrPipe()
-	if err := daemon.Start(); err != nil {
-		return fmt.Errorf("daemon start: %w", err)
-	}
-	shutdown := func() {
-		daemon.Process.Signal(syscall.SIGTERM)
-		done := make(chan struct{})
-		go func() { daemon.Wait(); close(done) }()
-		select {
-		case <-done:
-		case <-time.After(15 * time.Second):
-			daemon.Process.Kill()
-			daemon.Wait()
+
+	specs := defaultStrategies()
+	if *strategiesConfig != "" {
+		var err error
+		specs, err = loadStrategies(*strategiesConfig)
+		if err != nil {
+			return fmt.Errorf("load strategies config: %w", err)
 		}
 	}
-	defer shutdown()
 
-	// Handle Ctrl+C
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sig
-		fmt.Println("\nInterrupted, shutting down…")
-		shutdown()
-		os.Exit(1)
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
 
-	// Wait for "Daemon is ready"
-	stdoutSc := bufio.NewScanner(stdoutPipe)
-	ready := false
-	for stdoutSc.Scan() {
-		fmt.Printf("  %s\n", stdoutSc.Text())
-		if strings.Contains(stdoutSc.Text(), "Daemon is ready") {
-			ready = true
-			break
+	nodes := make([]harness.NodeConfig, len(specs))
+	for i, spec := range specs {
+		id, priv, err := identityFor(ctx, i, spec)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("strategy %q: %w", spec.Name, err)
 		}
-	}
-	if !ready {
-		return fmt.Errorf("daemon never became ready")
-	}
-	go func() {
-		for stdoutSc.Scan() {
+		port := basePort + i*10
+		nodes[i] = harness.NodeConfig{
+			Strategy: harness.StrategyConfig{
+				Name:                 spec.Name,
+				ProvideStrategy:      spec.ProvideStrategy,
+				SweepEnabled:         spec.SweepEnabled,
+				AcceleratedDHTClient: spec.AcceleratedDHTClient,
+				ReprovideInterval:    spec.ReprovideInterval,
+			},
+			RepoDir:     filepath.Join(dir, fmt.Sprintf(".ipfs-%s", spec.Name)),
+			SwarmPort:   port,
+			APIPort:     port + 1000,
+			GatewayPort: port + 4079,
+			PeerID:      id,
+			PrivKey:     priv,
+			Alerts:      alertsCfg,
+
+			VerifyInterval:       *verifyInterval,
+			VerifyRequestedPeers: *verifyPeers,
 		}
-	}()
+	}
 
-	// 4. Monitor provide logs
-	var (
-		mu         sync.Mutex
-		advertised int
-		records    int
-	)
+	cfg := harness.Config{
+		GeneratedDir: filepath.Join(dir, "generated_files"),
+		MetricsAddr:  *metricsAddr,
+		Nodes:        nodes,
+	}
 
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		sc := bufio.NewScanner(stderrPipe)
-		sc.Buffer(make([]byte, 0, 1<<20), 1<<20)
-		for sc.Scan() {
-			line := sc.Text()
-			if !strings.Contains(line, "sent provider record") {
-				continue
-			}
-			idx := strings.Index(line, "{")
-			if idx == -1 {
-				continue
-			}
-			var rec struct {
-				Keys   []string `json:"keys"`
-				Prefix string   `json:"prefix"`
-			}
-			if json.Unmarshal([]byte(line[idx:]), &rec) != nil {
-				continue
-			}
-
-			mu.Lock()
-			records++
-			newCnt := 0
-			for _, k := range rec.Keys {
-				raw, err := base64.StdEncoding.DecodeString(k)
-				if err != nil {
-					continue
-				}
-				if e, ok := tracked[string(raw)]; ok {
-					if e.count == 0 {
-						advertised++
-						newCnt++
-					}
-					e.count++
-				}
-			}
-			fmt.Printf("  [provide #%d] prefix=%s keys=%d new=%d | %d/%d\n",
-				records, rec.Prefix, len(rec.Keys), newCnt, advertised, total)
-			mu.Unlock()
-		}
+		<-sig
+		fmt.Println("\nInterrupted, shutting down…")
+		cancel()
 	}()
 
-	// Print distribution every minute until Ctrl+C
-	fmt.Printf("\nMonitoring provides (Ctrl+C to stop)…\n")
-	start := time.Now()
-	for {
-		time.Sleep(1 * time.Minute)
-		elapsed := time.Since(start).Round(time.Second)
-
-		mu.Lock()
-		fmt.Printf("\n%s\n", strings.Repeat("=", 60))
-		fmt.Printf("STATUS (%s elapsed, reprovide interval: %s)\n", elapsed, reprovideInterval)
-		fmt.Printf("  Total provide records: %d\n", records)
-		fmt.Printf("  CIDs advertised: %d/%d\n", advertised, total)
-		dist := make(map[int]int)
-		for _, e := range tracked {
-			dist[e.count]++
-		}
-		fmt.Printf("  Advertisement count distribution:\n")
-		for n := 0; n <= 100; n++ {
-			if cnt, ok := dist[n]; ok {
-				fmt.Printf("    %dx: %d CIDs\n", n, cnt)
-			}
-		}
-		fmt.Printf("%s\n", strings.Repeat("=", 60))
-		mu.Unlock()
+	report, err := harness.Run(ctx, cfg)
+	if err != nil {
+		return err
 	}
+	fmt.Print(report)
 	return nil
 }
 
 func main() {
+	flag.Parse()
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
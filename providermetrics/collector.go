@@ -0,0 +1,308 @@
+// Package providermetrics scrapes the "sent provider record" log lines
+// emitted by a Kubo daemon and turns them into typed counters/histograms,
+// so long-running harness sessions can be scraped by Prometheus/Grafana
+// instead of eyeballing stdout.
+package providermetrics
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// cidState is the provide history the collector keeps for a single
+// tracked multihash.
+type cidState struct {
+	cid       string
+	count     int
+	firstSeen time.Time
+	lastSeen  time.Time
+	gaps      []time.Duration     // inter-provide gaps, oldest first
+	prefixes  map[string]struct{} // DHT keyspace prefixes this CID was advertised under
+
+	// networkVerified and friends come from an independent DHT lookup
+	// (see the verifier package), as opposed to daemon-claims-provided
+	// which the fields above track.
+	networkVerified    bool
+	lastVerificationAt time.Time
+}
+
+// Collector scrapes daemon stderr for provide activity and exposes it
+// both as Prometheus metrics and as the human-readable console dump the
+// harness has always printed.
+type Collector struct {
+	mu      sync.Mutex
+	start   time.Time
+	tracked map[string]*cidState // multihash bytes (as string) -> state
+
+	records    int
+	advertised int
+
+	recordsTotal      prometheus.Counter
+	recordsByPrefix   *prometheus.CounterVec
+	keysPerRecord     prometheus.Histogram
+	firstSeenLatency  prometheus.Histogram
+	reprovideInterval prometheus.Histogram
+	advertisedGauge   prometheus.Gauge
+	trackedGauge      prometheus.Gauge
+
+	networkVerifiedGauge prometheus.Gauge
+	verificationLatency  prometheus.Histogram
+}
+
+// New creates a Collector for the given tracked CIDs, keyed by the
+// multihash bytes (as a string) that provide-record log lines reference,
+// mapped to the CID string used for display. Its metrics are registered
+// against reg, which lets callers standing up more than one Collector
+// (e.g. one per node in a multi-node harness run) keep them apart with
+// distinguishing labels via prometheus.WrapRegistererWith.
+func New(tracked map[string]string, reg prometheus.Registerer) *Collector {
+	c := &Collector{
+		start:   time.Now(),
+		tracked: make(map[string]*cidState, len(tracked)),
+
+		recordsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "provider_harness",
+			Name:      "provide_records_total",
+			Help:      `Total number of "sent provider record" log lines observed.`,
+		}),
+		recordsByPrefix: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "provider_harness",
+			Name:      "provide_records_by_prefix_total",
+			Help:      "Provide records observed, labeled by the DHT keyspace prefix they were sent for.",
+		}, []string{"prefix"}),
+		keysPerRecord: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "provider_harness",
+			Name:      "keys_per_record",
+			Help:      "Number of keys carried by each provide record.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		firstSeenLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "provider_harness",
+			Name:      "first_seen_latency_seconds",
+			Help:      "Time from harness start until a tracked CID was first advertised.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		reprovideInterval: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "provider_harness",
+			Name:      "reprovide_interval_seconds",
+			Help:      "Observed gap between two provide records for the same CID.",
+			Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+		}),
+		advertisedGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "provider_harness",
+			Name:      "cids_advertised",
+			Help:      "Number of tracked CIDs advertised at least once.",
+		}),
+		trackedGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "provider_harness",
+			Name:      "cids_tracked",
+			Help:      "Total number of CIDs the harness is tracking.",
+		}),
+		networkVerifiedGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "provider_harness",
+			Name:      "cids_network_verified",
+			Help:      "Number of tracked CIDs an independent DHT lookup confirmed are actually served, as opposed to just daemon-claimed.",
+		}),
+		verificationLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "provider_harness",
+			Name:      "verification_lookup_latency_seconds",
+			Help:      "Latency of the independent DHT lookup used to verify a CID is actually served.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	for hash, cidStr := range tracked {
+		c.tracked[hash] = &cidState{cid: cidStr}
+	}
+	c.trackedGauge.Set(float64(len(tracked)))
+
+	reg.MustRegister(c.recordsTotal, c.recordsByPrefix, c.keysPerRecord,
+		c.firstSeenLatency, c.reprovideInterval, c.advertisedGauge, c.trackedGauge,
+		c.networkVerifiedGauge, c.verificationLatency)
+
+	return c
+}
+
+// State is a point-in-time snapshot of one tracked CID's provide
+// history, for callers (such as the alerts engine) that need to reason
+// about individual CIDs rather than just the aggregate metrics.
+type State struct {
+	CID       string
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Gaps      []time.Duration
+	Prefixes  map[string]struct{}
+
+	// NetworkVerified and VerifiedAt come from an independent DHT lookup
+	// (see the verifier package), distinct from Count > 0 which only
+	// means the daemon claims to have provided it.
+	NetworkVerified bool
+	VerifiedAt      time.Time
+}
+
+// Snapshot returns the current state of every tracked CID, keyed by the
+// same multihash-bytes-as-string key ObserveLine matches records against.
+func (c *Collector) Snapshot() map[string]State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]State, len(c.tracked))
+	for hash, e := range c.tracked {
+		prefixes := make(map[string]struct{}, len(e.prefixes))
+		for p := range e.prefixes {
+			prefixes[p] = struct{}{}
+		}
+		out[hash] = State{
+			CID:             e.cid,
+			Count:           e.count,
+			FirstSeen:       e.firstSeen,
+			LastSeen:        e.lastSeen,
+			Gaps:            append([]time.Duration(nil), e.gaps...),
+			Prefixes:        prefixes,
+			NetworkVerified: e.networkVerified,
+			VerifiedAt:      e.lastVerificationAt,
+		}
+	}
+	return out
+}
+
+// VerificationResult is the subset of a verifier.Result the collector
+// needs to merge into a tracked CID's state. It's a plain struct rather
+// than importing the verifier package directly, so this package doesn't
+// depend on the libp2p/DHT client machinery the verifier pulls in.
+type VerificationResult struct {
+	Verified bool
+	Latency  time.Duration
+}
+
+// RecordVerification merges an independent DHT-lookup result into hash's
+// tracked state, distinguishing "daemon claims provided" (Count > 0)
+// from "network actually serves it" (NetworkVerified).
+func (c *Collector) RecordVerification(hash string, r VerificationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.tracked[hash]
+	if !ok {
+		return
+	}
+	c.verificationLatency.Observe(r.Latency.Seconds())
+
+	if r.Verified && !e.networkVerified {
+		c.networkVerifiedGauge.Inc()
+	} else if !r.Verified && e.networkVerified {
+		c.networkVerifiedGauge.Dec()
+	}
+	e.networkVerified = r.Verified
+	e.lastVerificationAt = time.Now()
+}
+
+// ObserveLine inspects a single line of daemon stderr and, if it is a
+// "sent provider record" log, updates the counters/histograms and the
+// per-CID tracking state. It reports whether the line matched.
+func (c *Collector) ObserveLine(line string) bool {
+	if !strings.Contains(line, "sent provider record") {
+		return false
+	}
+	idx := strings.Index(line, "{")
+	if idx == -1 {
+		return false
+	}
+	var rec struct {
+		Keys   []string `json:"keys"`
+		Prefix string   `json:"prefix"`
+	}
+	if json.Unmarshal([]byte(line[idx:]), &rec) != nil {
+		return false
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	c.records++
+	c.recordsTotal.Inc()
+	c.recordsByPrefix.WithLabelValues(rec.Prefix).Inc()
+	c.keysPerRecord.Observe(float64(len(rec.Keys)))
+
+	newCnt := 0
+	for _, k := range rec.Keys {
+		raw, err := base64.StdEncoding.DecodeString(k)
+		if err != nil {
+			continue
+		}
+		e, ok := c.tracked[string(raw)]
+		if !ok {
+			continue
+		}
+		if e.prefixes == nil {
+			e.prefixes = make(map[string]struct{})
+		}
+		e.prefixes[rec.Prefix] = struct{}{}
+		if e.count == 0 {
+			c.advertised++
+			c.advertisedGauge.Set(float64(c.advertised))
+			e.firstSeen = now
+			c.firstSeenLatency.Observe(now.Sub(c.start).Seconds())
+			newCnt++
+		} else {
+			gap := now.Sub(e.lastSeen)
+			c.reprovideInterval.Observe(gap.Seconds())
+			e.gaps = append(e.gaps, gap)
+		}
+		e.count++
+		e.lastSeen = now
+	}
+	records, advertised, total := c.records, c.advertised, len(c.tracked)
+	c.mu.Unlock()
+
+	fmt.Printf("  [provide #%d] prefix=%s keys=%d new=%d | %d/%d\n",
+		records, rec.Prefix, len(rec.Keys), newCnt, advertised, total)
+	return true
+}
+
+// DumpStatus prints the same human-readable status block the harness has
+// always printed, as a second sink alongside the Prometheus metrics.
+func (c *Collector) DumpStatus(w io.Writer, elapsed, reprovideInterval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "\n%s\n", strings.Repeat("=", 60))
+	fmt.Fprintf(w, "STATUS (%s elapsed, reprovide interval: %s)\n", elapsed.Round(time.Second), reprovideInterval)
+	fmt.Fprintf(w, "  Total provide records: %d\n", c.records)
+	fmt.Fprintf(w, "  CIDs advertised (daemon claims): %d/%d\n", c.advertised, len(c.tracked))
+	verified := 0
+	for _, e := range c.tracked {
+		if e.networkVerified {
+			verified++
+		}
+	}
+	fmt.Fprintf(w, "  CIDs network-verified (independent DHT lookup): %d/%d\n", verified, len(c.tracked))
+	dist := make(map[int]int)
+	for _, e := range c.tracked {
+		dist[e.count]++
+	}
+	fmt.Fprintf(w, "  Advertisement count distribution:\n")
+	for n := 0; n <= 100; n++ {
+		if cnt, ok := dist[n]; ok {
+			fmt.Fprintf(w, "    %dx: %d CIDs\n", n, cnt)
+		}
+	}
+	fmt.Fprintf(w, "%s\n", strings.Repeat("=", 60))
+}
+
+// ServeMetrics starts an HTTP server exposing reg at /metrics in
+// Prometheus exposition format. It blocks until the server stops, so
+// callers typically run it in its own goroutine.
+func ServeMetrics(addr string, reg prometheus.Gatherer) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	return http.ListenAndServe(addr, mux)
+}
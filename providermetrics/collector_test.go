@@ -0,0 +1,76 @@
+package providermetrics
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// provideLine builds a fake "sent provider record" daemon stderr line
+// carrying a single base64-encoded multihash and prefix, matching the
+// shape ObserveLine scrapes.
+func provideLine(rawHash []byte, prefix string) string {
+	key := base64.StdEncoding.EncodeToString(rawHash)
+	return fmt.Sprintf(`2024-01-01T00:00:00.000Z debug dht/provider: sent provider record {"keys":["%s"],"prefix":"%s"}`, key, prefix)
+}
+
+func TestObserveLineIgnoresUnrelatedLines(t *testing.T) {
+	c := New(nil, prometheus.NewRegistry())
+	if c.ObserveLine("2024-01-01T00:00:00.000Z info some other daemon log line") {
+		t.Fatal("expected an unrelated line not to match")
+	}
+}
+
+func TestObserveLineTracksNewCID(t *testing.T) {
+	rawHash := []byte("raw-multihash-a")
+	tracked := map[string]string{string(rawHash): "bafy-a"}
+	c := New(tracked, prometheus.NewRegistry())
+
+	if !c.ObserveLine(provideLine(rawHash, "1010")) {
+		t.Fatal("expected a matching provide-record line to be observed")
+	}
+
+	s := c.Snapshot()[string(rawHash)]
+	if s.Count != 1 {
+		t.Fatalf("Count = %d, want 1", s.Count)
+	}
+	if _, ok := s.Prefixes["1010"]; !ok {
+		t.Fatalf("Prefixes = %v, want to contain %q", s.Prefixes, "1010")
+	}
+	if len(s.Gaps) != 0 {
+		t.Fatalf("Gaps = %v, want none on first sighting", s.Gaps)
+	}
+}
+
+func TestObserveLineRecordsGapOnRepeat(t *testing.T) {
+	rawHash := []byte("raw-multihash-b")
+	tracked := map[string]string{string(rawHash): "bafy-b"}
+	c := New(tracked, prometheus.NewRegistry())
+
+	c.ObserveLine(provideLine(rawHash, "0000"))
+	c.ObserveLine(provideLine(rawHash, "0000"))
+
+	s := c.Snapshot()[string(rawHash)]
+	if s.Count != 2 {
+		t.Fatalf("Count = %d, want 2", s.Count)
+	}
+	if len(s.Gaps) != 1 {
+		t.Fatalf("Gaps = %v, want exactly one gap after a second sighting", s.Gaps)
+	}
+}
+
+func TestObserveLineSkipsUntrackedKeys(t *testing.T) {
+	c := New(map[string]string{string([]byte("tracked")): "bafy-tracked"}, prometheus.NewRegistry())
+
+	matched := c.ObserveLine(provideLine([]byte("not-tracked"), "0000"))
+
+	if !matched {
+		t.Fatal("expected the line to still be recognized as a provide record")
+	}
+	s := c.Snapshot()[string([]byte("tracked"))]
+	if s.Count != 0 {
+		t.Fatalf("Count = %d, want 0: the observed key isn't tracked", s.Count)
+	}
+}
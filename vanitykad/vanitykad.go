@@ -0,0 +1,240 @@
+// Package vanitykad generates libp2p ed25519 peer identities whose
+// Kademlia ID (sha256 of the peer ID bytes) starts with a given number of
+// zero bits, sharding the search across workers. It's the reusable form of
+// what used to be a single-threaded, one-byte-prefix-only main package.
+package vanitykad
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Stats reports live or final progress for a Generate call.
+type Stats struct {
+	Attempts uint64
+	Elapsed  time.Duration
+	Rate     float64       // attempts/sec
+	ETA      time.Duration // expected remaining time given a 2^prefixBits search space
+}
+
+// Checkpoint seeds Generate's reported Stats with attempts already spent
+// in a previous run. The search itself is memoryless (every attempt is
+// an independent random ed25519 key), so a checkpoint is nothing more
+// than the running attempt count: there's no partial state to restore.
+type Checkpoint struct {
+	Attempts uint64 `json:"attempts"`
+}
+
+// LoadCheckpoint reads a Checkpoint previously written by SaveCheckpoint.
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Checkpoint{}, fmt.Errorf("parse checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// SaveCheckpoint writes c to path as JSON, overwriting any previous
+// checkpoint. Callers typically call this from a ProgressFunc to
+// periodically persist attempts already spent, so a later Generate call
+// can resume from roughly where an interrupted run left off.
+func SaveCheckpoint(path string, c Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ProgressFunc is invoked a few times a second with live Stats while
+// Generate is searching.
+type ProgressFunc func(Stats)
+
+// KadID returns the Kademlia ID libp2p-kad-dht uses for id: sha256 of the
+// raw peer ID bytes.
+func KadID(id peer.ID) [32]byte {
+	return sha256.Sum256([]byte(id))
+}
+
+// Generate searches for an ed25519 peer identity whose Kademlia ID has
+// at least prefixBits leading zero bits, sharding ed25519 keygen across
+// workers goroutines (workers <= 0 means runtime.NumCPU()). It reports
+// live attempts/sec and ETA to progress, if non-nil, and returns once a
+// match is found or ctx is done.
+func Generate(ctx context.Context, prefixBits int, workers int, resume *Checkpoint, progress ProgressFunc) (peer.ID, crypto.PrivKey, Stats, error) {
+	if prefixBits < 0 || prefixBits > 256 {
+		return "", nil, Stats{}, fmt.Errorf("prefixBits must be in [0, 256], got %d", prefixBits)
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	type match struct {
+		id   peer.ID
+		priv crypto.PrivKey
+	}
+
+	var resumed uint64
+	if resume != nil {
+		resumed = resume.Attempts
+	}
+	attempts := resumed
+	start := time.Now()
+	expected := math.Pow(2, float64(prefixBits))
+
+	statsNow := func() Stats {
+		n := atomic.LoadUint64(&attempts)
+		elapsed := time.Since(start)
+		rate := float64(n-resumed) / elapsed.Seconds()
+		var eta time.Duration
+		if rate > 0 {
+			remaining := expected - float64(n)
+			if remaining < 0 {
+				remaining = 0
+			}
+			eta = time.Duration(remaining/rate) * time.Second
+		}
+		return Stats{Attempts: n, Elapsed: elapsed, Rate: rate, ETA: eta}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan match, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				pub, priv, err := ed25519.GenerateKey(rand.Reader)
+				if err != nil {
+					continue
+				}
+				atomic.AddUint64(&attempts, 1)
+
+				// Identity multihash of the marshalled pubkey, as libp2p
+				// peer IDs are derived, kept inline here for speed since
+				// it runs on every attempt.
+				marshalledPub := append([]byte{0x08, 0x01, 0x12, 0x20}, pub...)
+				peerIDBytes := append([]byte{0x00, 0x24}, marshalledPub...)
+				kadID := sha256.Sum256(peerIDBytes)
+
+				if !matchesPrefix(kadID[:], prefixBits) {
+					continue
+				}
+
+				seedAndPub := append(append([]byte{}, priv.Seed()...), pub...)
+				libp2pPriv, err := crypto.UnmarshalEd25519PrivateKey(seedAndPub)
+				if err != nil {
+					continue
+				}
+				id, err := peer.IDFromPrivateKey(libp2pPriv)
+				if err != nil {
+					continue
+				}
+
+				select {
+				case found <- match{id: id, priv: libp2pPriv}:
+					cancel()
+				default:
+				}
+				return
+			}
+		}()
+	}
+
+	if progress != nil {
+		go func() {
+			ticker := time.NewTicker(250 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					progress(statsNow())
+				}
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	select {
+	case m := <-found:
+		return m.id, m.priv, statsNow(), nil
+	default:
+		return "", nil, Stats{}, ctx.Err()
+	}
+}
+
+// matchesPrefix reports whether digest's leading prefixBits bits are
+// all zero.
+func matchesPrefix(digest []byte, prefixBits int) bool {
+	fullBytes := prefixBits / 8
+	for i := 0; i < fullBytes; i++ {
+		if digest[i] != 0 {
+			return false
+		}
+	}
+	remBits := prefixBits % 8
+	if remBits == 0 {
+		return true
+	}
+	mask := byte(0xFF << (8 - remBits))
+	return digest[fullBytes]&mask == 0
+}
+
+// BucketIndex returns the common-prefix length (in bits) of kadID XORed
+// with target, i.e. the DHT bucket index a peer with Kademlia ID kadID
+// would land in relative to target.
+func BucketIndex(kadID, target [32]byte) int {
+	var xor [32]byte
+	for i := range xor {
+		xor[i] = kadID[i] ^ target[i]
+	}
+	n := 0
+	for _, b := range xor {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		for bit := 7; bit >= 0; bit-- {
+			if b&(1<<bit) == 0 {
+				n++
+			} else {
+				return n
+			}
+		}
+		return n
+	}
+	return n
+}
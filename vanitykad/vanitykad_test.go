@@ -0,0 +1,32 @@
+package vanitykad
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// BenchmarkGenerate_Bucket verifies the harness can produce a peer whose
+// Kademlia ID lands in a user-specified DHT bucket relative to a target
+// key (here the zero key, the bucket-boundary convention used elsewhere
+// in this package) within a bounded time budget. It's a benchmark rather
+// than a test because runtime scales exponentially with prefixBits; run
+// explicitly with `go test -bench BenchmarkGenerate_Bucket -run ^$`.
+func BenchmarkGenerate_Bucket(b *testing.B) {
+	const prefixBits = 12 // cheap enough for CI: ~4096 expected attempts
+
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		id, _, stats, err := Generate(ctx, prefixBits, 0, nil, nil)
+		cancel()
+		if err != nil {
+			b.Fatalf("generate: %v", err)
+		}
+
+		var target [32]byte
+		if bucket := BucketIndex(KadID(id), target); bucket < prefixBits {
+			b.Fatalf("peer %s landed in bucket %d, want >= %d", id, bucket, prefixBits)
+		}
+		b.Logf("found in %d attempts (%s)", stats.Attempts, stats.Elapsed)
+	}
+}
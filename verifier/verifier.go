@@ -0,0 +1,191 @@
+// Package verifier independently checks, via a lightweight libp2p DHT
+// client, whether a CID is actually served by the network — as opposed
+// to trusting the daemon's own "sent provider record" log lines, which
+// only say the daemon attempted to advertise it.
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Result is the outcome of verifying a single CID against the live DHT.
+type Result struct {
+	CID       string
+	CheckedAt time.Time
+	// Requested is the maximum number of providers the lookup asks for.
+	Requested int
+	// RespondingPeers is the number of providers the lookup returned, at
+	// most Requested. The lookup does not report which DHT peers served
+	// the records, so this is not a count of the closest peers holding one.
+	RespondingPeers int
+	// ClosestCPL and FarthestCPL are the common-prefix-length (in bits,
+	// higher = closer) between sha256(mh) and the Kademlia ID of the
+	// closest and farthest returned providers.
+	ClosestCPL  int
+	FarthestCPL int
+	Latency     time.Duration
+	// Err is set if the lookup itself failed, as opposed to simply
+	// returning zero providers.
+	Err error
+}
+
+// Verified reports whether the lookup returned at least one provider.
+func (r Result) Verified() bool {
+	return r.Err == nil && r.RespondingPeers > 0
+}
+
+// Verifier is an independent, client-mode libp2p host and DHT client,
+// so verification results can't be confused with the daemon under test.
+type Verifier struct {
+	host      host.Host
+	dht       *dht.IpfsDHT
+	requested int
+	timeout   time.Duration
+}
+
+// New starts a client-mode libp2p host, dials the network's well-known
+// bootstrap peers, and bootstraps a DHT client against them. It serves
+// no content itself.
+func New(ctx context.Context, requested int, timeout time.Duration) (*Verifier, error) {
+	h, err := libp2p.New()
+	if err != nil {
+		return nil, fmt.Errorf("verifier: new host: %w", err)
+	}
+	kad, err := dht.New(ctx, h, dht.Mode(dht.ModeClient))
+	if err != nil {
+		h.Close()
+		return nil, fmt.Errorf("verifier: new dht client: %w", err)
+	}
+
+	if connected := connectBootstrap(ctx, h, timeout); connected == 0 {
+		h.Close()
+		return nil, fmt.Errorf("verifier: could not dial any bootstrap peer")
+	}
+
+	if err := kad.Bootstrap(ctx); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("verifier: bootstrap: %w", err)
+	}
+	return &Verifier{host: h, dht: kad, requested: requested, timeout: timeout}, nil
+}
+
+// connectBootstrap dials h to every one of the DHT's default bootstrap
+// peers in parallel and reports how many succeeded. Without at least one
+// of these connections the host's peerstore and routing table are empty,
+// so Bootstrap has nothing to query and FindProvidersAsync would never
+// return a result regardless of real network state.
+func connectBootstrap(ctx context.Context, h host.Host, timeout time.Duration) int {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		connected int
+	)
+	for _, addr := range dht.DefaultBootstrapPeers {
+		addr := addr
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, err := peer.AddrInfoFromP2pAddr(addr)
+			if err != nil {
+				return
+			}
+			dialCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if err := h.Connect(dialCtx, *info); err == nil {
+				mu.Lock()
+				connected++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return connected
+}
+
+// Close shuts down the verifier's host and DHT client.
+func (v *Verifier) Close() error {
+	dhtErr := v.dht.Close()
+	hostErr := v.host.Close()
+	if dhtErr != nil {
+		return dhtErr
+	}
+	return hostErr
+}
+
+// Verify runs `findprovs`-equivalent DHT lookup for c against the live
+// network and reports how many providers (at most v.requested) it
+// returned and how close their Kademlia IDs are to sha256(c.Hash()).
+func (v *Verifier) Verify(ctx context.Context, c cid.Cid) Result {
+	start := time.Now()
+	res := Result{CID: c.String(), CheckedAt: start, Requested: v.requested}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	target := sha256.Sum256(c.Hash())
+	closest, farthest := -1, 257
+
+	ch := v.dht.FindProvidersAsync(ctx, c, v.requested)
+	for p := range ch {
+		cpl := commonPrefixLen(xorDistance(kadID(p.ID), target))
+		if cpl > closest {
+			closest = cpl
+		}
+		if cpl < farthest {
+			farthest = cpl
+		}
+		res.RespondingPeers++
+	}
+	res.Latency = time.Since(start)
+
+	if res.RespondingPeers > 0 {
+		res.ClosestCPL, res.FarthestCPL = closest, farthest
+	} else if err := ctx.Err(); err != nil {
+		res.Err = fmt.Errorf("findprovs: %w", err)
+	}
+	return res
+}
+
+// kadID is the Kademlia ID libp2p-kad-dht uses for a peer: sha256 of the
+// raw peer ID bytes.
+func kadID(p peer.ID) [32]byte {
+	return sha256.Sum256([]byte(p))
+}
+
+func xorDistance(a, b [32]byte) [32]byte {
+	var d [32]byte
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return d
+}
+
+// commonPrefixLen returns the number of leading zero bits in d.
+func commonPrefixLen(d [32]byte) int {
+	n := 0
+	for _, b := range d {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		for bit := 7; bit >= 0; bit-- {
+			if b&(1<<bit) == 0 {
+				n++
+			} else {
+				return n
+			}
+		}
+		return n
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestKadID(t *testing.T) {
+	id := peer.ID("some-raw-peer-id-bytes")
+	want := sha256.Sum256([]byte(id))
+	if got := kadID(id); got != want {
+		t.Fatalf("kadID(%q) = %x, want %x", id, got, want)
+	}
+}
+
+func TestXorDistance(t *testing.T) {
+	var a, b [32]byte
+	a[0], a[31] = 0xFF, 0x01
+	b[0], b[31] = 0x0F, 0x01
+
+	got := xorDistance(a, b)
+
+	var want [32]byte
+	want[0] = 0xF0
+	if got != want {
+		t.Fatalf("xorDistance = %x, want %x", got, want)
+	}
+}
+
+func TestXorDistanceIsZeroForIdenticalInputs(t *testing.T) {
+	var a [32]byte
+	a[0], a[15] = 0x42, 0x07
+
+	var zero [32]byte
+	if got := xorDistance(a, a); got != zero {
+		t.Fatalf("xorDistance(a, a) = %x, want all zero", got)
+	}
+}
+
+func TestCommonPrefixLenAllZero(t *testing.T) {
+	var d [32]byte
+	if got := commonPrefixLen(d); got != 256 {
+		t.Fatalf("commonPrefixLen(all zero) = %d, want 256", got)
+	}
+}
+
+func TestCommonPrefixLenFirstBitSet(t *testing.T) {
+	var d [32]byte
+	d[0] = 0x80
+	if got := commonPrefixLen(d); got != 0 {
+		t.Fatalf("commonPrefixLen(first bit set) = %d, want 0", got)
+	}
+}
+
+func TestCommonPrefixLenMidByte(t *testing.T) {
+	var d [32]byte
+	d[1] = 0x0F // byte 0 all zero (8 bits), then 4 leading zero bits in byte 1
+	if got := commonPrefixLen(d); got != 12 {
+		t.Fatalf("commonPrefixLen(mid byte) = %d, want 12", got)
+	}
+}